@@ -0,0 +1,340 @@
+// Command optionsgen generates `WithXxx` option constructors for a struct,
+// so packages following the pattern in options/main.go don't have to
+// hand-write one function per field.
+//
+// Usage, via a go:generate directive next to the target struct:
+//
+//	//go:generate optionsgen Server
+//
+// For every exported field of the named struct, optionsgen emits a
+// `WithXxx(value Type) Option` function that sets that field. The field's
+// doc comment, if any, is copied onto the generated function. A struct tag
+// of the form `options:"name,default"` renames the option (WithName instead
+// of WithFieldName) and, if a default is given, makes the generated option
+// apply that default whenever it's called with the zero value of the
+// field's type; a tag of `options:"-"` skips the field entirely. Fields
+// whose type is itself a struct are expanded into a multi-argument option,
+// one argument per exported sub-field; defaults aren't supported on those.
+// Whatever struct is named, the generated functions are written against
+// the Option, optionFunc and *Server names of options/main.go.
+//
+// Add an `options:"-"` tag to a field that already has a hand-written
+// WithXxx: otherwise running the generator would emit a second definition
+// with the same name and fail to compile.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// field describes a single generated option argument.
+type field struct {
+	ArgName string
+	Type    string
+
+	// Path is the selector the argument is assigned to on the struct,
+	// e.g. "Addr.Host" for a nested struct field.
+	Path string
+}
+
+// optionSpec describes one WithXxx function to generate.
+type optionSpec struct {
+	OptionName string
+	Doc        string
+	Fields     []field
+
+	// Default and Zero are Go expressions for the option's single argument;
+	// both are empty when the field has no default (or has more than one
+	// generated argument).
+	Default string
+	Zero    string
+}
+
+var optionTmpl = template.Must(template.New("option").Parse(`
+{{- if .Doc}}// {{.Doc}}
+{{end -}}
+func With{{.OptionName}}({{range $i, $f := .Fields}}{{if $i}}, {{end}}{{$f.ArgName}} {{$f.Type}}{{end}}) Option {
+	return optionFunc(func(s *Server) error {
+{{- if .Default}}
+		if {{(index .Fields 0).ArgName}} == {{.Zero}} {
+			{{(index .Fields 0).ArgName}} = {{.Default}}
+		}
+{{- end}}
+{{- range .Fields}}
+		s.{{.Path}} = {{.ArgName}}
+{{- end}}
+		return nil
+	})
+}
+`))
+
+const mustParseDurationHelper = `
+// mustParseDuration parses a default tag value for a time.Duration field.
+// It panics on a malformed tag because that's an optionsgen input error,
+// not something callers of the generated option can fix.
+func mustParseDuration(s string) time.Duration {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		panic(fmt.Sprintf("optionsgen: invalid default duration %q: %v", s, err))
+	}
+
+	return d
+}
+`
+
+func main() {
+	var path, structName string
+
+	switch len(os.Args) {
+	case 2:
+		// Run from a go:generate directive: go generate names the file
+		// holding the directive in $GOFILE.
+		path, structName = os.Getenv("GOFILE"), os.Args[1]
+	case 3:
+		path, structName = os.Args[1], os.Args[2]
+	default:
+		fmt.Fprintln(os.Stderr, "usage: optionsgen [<file.go>] <StructName>")
+		os.Exit(1)
+	}
+
+	if err := run(path, structName); err != nil {
+		fmt.Fprintln(os.Stderr, "optionsgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(path, structName string) error {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	st := findStruct(file, structName)
+	if st == nil {
+		return fmt.Errorf("struct %s not found in %s", structName, path)
+	}
+
+	specs, needsDuration, err := buildSpecs(st)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "// Code generated by optionsgen from %s; DO NOT EDIT.\n\npackage %s\n", structName, file.Name.Name)
+
+	if needsDuration {
+		buf.WriteString("\nimport (\n\t\"fmt\"\n\t\"time\"\n)\n")
+		buf.WriteString(mustParseDurationHelper)
+	}
+
+	for _, spec := range specs {
+		if err := optionTmpl.Execute(&buf, spec); err != nil {
+			return fmt.Errorf("render option for %s: %w", spec.OptionName, err)
+		}
+	}
+
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("format generated source: %w", err)
+	}
+
+	outPath := strings.TrimSuffix(path, ".go") + "_options_gen.go"
+
+	return os.WriteFile(outPath, out, 0o644)
+}
+
+// findStruct locates the *ast.StructType for the named type in file.
+func findStruct(file *ast.File, name string) *ast.StructType {
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok || ts.Name.Name != name {
+				continue
+			}
+
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				return st
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildSpecs turns a struct's exported fields into option specs, honoring
+// `options:"..."` tags and expanding nested struct fields. It also reports
+// whether any generated default needs the time.ParseDuration helper.
+func buildSpecs(st *ast.StructType) ([]optionSpec, bool, error) {
+	var (
+		specs         []optionSpec
+		needsDuration bool
+	)
+
+	for _, f := range st.Fields.List {
+		for _, name := range f.Names {
+			if !name.IsExported() {
+				continue
+			}
+
+			optName, defaultVal, skip := parseTag(f.Tag)
+			if skip {
+				continue
+			}
+
+			if optName == "" {
+				optName = name.Name
+			}
+
+			fields, err := typeFields(name.Name, f.Type)
+			if err != nil {
+				return nil, false, fmt.Errorf("field %s: %w", name.Name, err)
+			}
+
+			spec := optionSpec{
+				OptionName: optName,
+				Doc:        strings.TrimSpace(f.Doc.Text()),
+				Fields:     fields,
+			}
+
+			// Defaults only make sense for a single scalar argument; nested
+			// struct fields expand into several arguments with no single
+			// value to default.
+			if defaultVal != "" && len(fields) == 1 {
+				lit, needsHelper := defaultLiteral(fields[0].Type, defaultVal)
+				spec.Default = lit
+				spec.Zero = zeroLiteral(fields[0].Type)
+				needsDuration = needsDuration || needsHelper
+
+				spec.Doc = strings.TrimSuffix(spec.Doc, ".")
+				if spec.Doc != "" {
+					spec.Doc += " "
+				}
+				spec.Doc += fmt.Sprintf("Defaults to %s.", defaultVal)
+			}
+
+			specs = append(specs, spec)
+		}
+	}
+
+	return specs, needsDuration, nil
+}
+
+// zeroLiteral returns the Go zero-value literal for typ, used to detect
+// "caller didn't pass a value" so the default can be substituted in.
+func zeroLiteral(typ string) string {
+	switch typ {
+	case "string":
+		return `""`
+	case "bool":
+		return "false"
+	default:
+		return "0"
+	}
+}
+
+// defaultLiteral renders a tag's default value as a Go expression of type
+// typ, reporting whether the expression needs the mustParseDuration helper.
+func defaultLiteral(typ, raw string) (string, bool) {
+	switch typ {
+	case "string":
+		return fmt.Sprintf("%q", raw), false
+	case "time.Duration":
+		return fmt.Sprintf("mustParseDuration(%q)", raw), true
+	default:
+		// Numeric and bool defaults are assumed to already be valid Go
+		// literals (e.g. `20`, `true`).
+		return raw, false
+	}
+}
+
+// typeFields expands a field into one or more generated-function arguments.
+// Nested struct literals become one argument per exported sub-field;
+// everything else becomes a single argument named after the field.
+func typeFields(fieldName string, expr ast.Expr) ([]field, error) {
+	if st, ok := expr.(*ast.StructType); ok {
+		var fields []field
+
+		for _, sub := range st.Fields.List {
+			for _, name := range sub.Names {
+				if !name.IsExported() {
+					continue
+				}
+
+				typ, err := exprString(sub.Type)
+				if err != nil {
+					return nil, err
+				}
+
+				fields = append(fields, field{
+					ArgName: fieldName + name.Name,
+					Type:    typ,
+					Path:    fieldName + "." + name.Name,
+				})
+			}
+		}
+
+		return fields, nil
+	}
+
+	typ, err := exprString(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return []field{{ArgName: fieldName, Type: typ, Path: fieldName}}, nil
+}
+
+// exprString renders a type expression back to source form.
+func exprString(expr ast.Expr) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, token.NewFileSet(), expr); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// parseTag reads the `options:"name,default"` struct tag. A name of "-"
+// means skip is true.
+func parseTag(tag *ast.BasicLit) (name, def string, skip bool) {
+	if tag == nil {
+		return "", "", false
+	}
+
+	value := strings.Trim(tag.Value, "`")
+
+	raw, ok := reflect.StructTag(value).Lookup("options")
+	if !ok {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(raw, ",", 2)
+
+	if parts[0] == "-" {
+		return "", "", true
+	}
+
+	name = parts[0]
+	if len(parts) > 1 {
+		def = parts[1]
+	}
+
+	return name, def, false
+}
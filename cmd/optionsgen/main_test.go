@@ -0,0 +1,75 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixtureSrc = `package main
+
+type Target struct {
+	// Name of the target.
+	Name string
+
+	// Skipped is never surfaced as an option.
+	Skipped string ` + "`options:\"-\"`" + `
+
+	// Retries controls how many attempts are made.
+	Retries uint ` + "`options:\"retries,3\"`" + `
+
+	// Timeout bounds how long a single attempt may take.
+	Timeout time.Duration ` + "`options:\"timeout,5s\"`" + `
+}
+`
+
+// TestRunGeneratesValidOption runs the generator end to end against a
+// fixture struct and checks the output is syntactically valid Go whose
+// option constructors satisfy the Option interface via optionFunc, and
+// that tag-provided defaults are actually applied rather than only
+// mentioned in a comment.
+func TestRunGeneratesValidOption(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "target.go")
+
+	if err := os.WriteFile(src, []byte(fixtureSrc), 0o644); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+
+	if err := run(src, "Target"); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+
+	genPath := strings.TrimSuffix(src, ".go") + "_options_gen.go"
+
+	out, err := os.ReadFile(genPath)
+	if err != nil {
+		t.Fatalf("read generated file: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	if _, err := parser.ParseFile(fset, genPath, out, parser.AllErrors); err != nil {
+		t.Fatalf("generated file is not valid Go: %v\n%s", err, out)
+	}
+
+	got := string(out)
+
+	if !strings.Contains(got, "return optionFunc(func(s *Server) error {") {
+		t.Errorf("generated option body is not wrapped in optionFunc:\n%s", got)
+	}
+
+	if strings.Contains(got, "func WithSkipped(") {
+		t.Errorf("options:\"-\" field was generated anyway:\n%s", got)
+	}
+
+	if !strings.Contains(got, `Retries = 3`) {
+		t.Errorf("numeric default was not applied in generated code:\n%s", got)
+	}
+
+	if !strings.Contains(got, `Timeout = mustParseDuration("5s")`) {
+		t.Errorf("duration default was not applied in generated code:\n%s", got)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldKey is the config/env name for each Server field: the field name,
+// which is also the option name optionsgen generates by default,
+// lower-cased with underscores between words (host, max_idle_connections, ...).
+var fieldKey = map[string]string{
+	"Name":                 "name",
+	"Host":                 "host",
+	"MaxIdleConnections":   "max_idle_connections",
+	"MaxSessionConnection": "max_session_connection",
+}
+
+// setField applies a single raw string value, read from a config file or
+// environment variable, to the matching Server field.
+func setField(s *Server, field, raw string) error {
+	switch field {
+	case "Name":
+		s.Name = raw
+	case "Host":
+		return WithHost(raw).apply(s)
+	case "MaxIdleConnections":
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("options: invalid max_idle_connections %q: %w", raw, err)
+		}
+
+		return WithMaxIdleConnections(uint(n)).apply(s)
+	case "MaxSessionConnection":
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("options: invalid max_session_connection %q: %w", raw, err)
+		}
+
+		return WithMaxSessionConnection(d).apply(s)
+	default:
+		return fmt.Errorf("options: unknown field %q", field)
+	}
+
+	return nil
+}
+
+// FromEnv reads SERVER fields from environment variables named
+// "<prefix>_<FIELD_KEY>", e.g. FromEnv("SERVER") reads SERVER_HOST,
+// SERVER_MAX_IDLE_CONNECTIONS, and so on. Variables that aren't set are
+// left untouched.
+func FromEnv(prefix string) Option {
+	return optionFunc(func(s *Server) error {
+		for field, key := range fieldKey {
+			envKey := prefix + "_" + strings.ToUpper(key)
+
+			raw, ok := os.LookupEnv(envKey)
+			if !ok {
+				continue
+			}
+
+			if err := setField(s, field, raw); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// FromFile reads Server fields from a config file, chosen by the file's
+// extension (.json, .yaml/.yml, .toml). Keys are the same lower-case field
+// names as FromEnv, optionally prefixed with "server.". Unknown keys are
+// reported as errors.
+//
+// JSON is parsed in full via encoding/json. YAML and TOML are NOT: both are
+// parsed as a flat "key: value" / "key = value" list, one entry per line
+// (see parseFlatKV) — nested YAML mappings and TOML "[section]" tables
+// aren't supported. That's enough for a flat Server config; reach for a
+// real YAML/TOML library if this package ever needs more.
+func FromFile(path string) Option {
+	return optionFunc(func(s *Server) error {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("options: read %s: %w", path, err)
+		}
+
+		values, err := parseConfig(path, raw)
+		if err != nil {
+			return fmt.Errorf("options: parse %s: %w", path, err)
+		}
+
+		for key, value := range values {
+			field, ok := fieldForKey(strings.TrimPrefix(key, "server."))
+			if !ok {
+				return fmt.Errorf("options: %s: unknown key %q", path, key)
+			}
+
+			if err := setField(s, field, value); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// fieldForKey reverses fieldKey: it maps a config/env key back to the
+// Server field name it configures.
+func fieldForKey(key string) (string, bool) {
+	for field, k := range fieldKey {
+		if k == key {
+			return field, true
+		}
+	}
+
+	return "", false
+}
+
+// parseConfig reads path's extension to pick a format and returns its
+// contents as a flat map of key to string value.
+func parseConfig(path string, raw []byte) (map[string]string, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		return parseJSON(raw)
+	case ".yaml", ".yml":
+		return parseFlatKV(raw, ":")
+	case ".toml":
+		return parseFlatKV(raw, "=")
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", ext)
+	}
+}
+
+// parseJSON flattens a JSON object of scalars into string values. It decodes
+// numbers as json.Number rather than float64, so a value like 1000000 comes
+// out as "1000000" instead of fmt.Sprint's "1e+06", which ParseUint and
+// ParseDuration both reject.
+func parseJSON(raw []byte) (map[string]string, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+
+	var fields map[string]interface{}
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+
+	out := make(map[string]string, len(fields))
+	for k, v := range fields {
+		if n, ok := v.(json.Number); ok {
+			out[k] = n.String()
+			continue
+		}
+
+		out[k] = fmt.Sprint(v)
+	}
+
+	return out, nil
+}
+
+// parseFlatKV parses a flat "key<sep>value" file, one entry per line,
+// ignoring blank lines and "#" comments ("#" is a comment marker in both
+// YAML and TOML) and trimming quotes around values. This is a restricted
+// flat-key-value subset of YAML/TOML, not a full parser: it doesn't handle
+// YAML indentation/nesting or TOML "[section]" tables.
+func parseFlatKV(raw []byte, sep string) (map[string]string, error) {
+	out := map[string]string{}
+
+	for _, line := range strings.Split(string(raw), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, sep, 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+
+		key := strings.TrimSpace(parts[0])
+		value := strings.Trim(strings.TrimSpace(parts[1]), `"'`)
+
+		out[key] = value
+	}
+
+	return out, nil
+}
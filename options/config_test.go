@@ -0,0 +1,171 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+
+	return path
+}
+
+func TestFromFileJSON(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.json", `{
+		"name": "configured",
+		"host": "https://configured.eu",
+		"max_idle_connections": 1000000,
+		"max_session_connection": "2m"
+	}`)
+
+	s, err := New(FromFile(path))
+	if err != nil {
+		t.Fatalf("New(FromFile(%q)) error = %v", path, err)
+	}
+
+	want := Server{
+		Name:                 "configured",
+		Host:                 "https://configured.eu",
+		MaxIdleConnections:   1000000,
+		MaxSessionConnection: 2 * time.Minute,
+	}
+
+	if s != want {
+		t.Errorf("New(FromFile(%q)) = %+v, want %+v", path, s, want)
+	}
+}
+
+func TestFromFileYAML(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.yaml", "# comment\nhost: https://yaml.eu\nmax_idle_connections: 7\n")
+
+	s, err := New(FromFile(path))
+	if err != nil {
+		t.Fatalf("New(FromFile(%q)) error = %v", path, err)
+	}
+
+	if s.Host != "https://yaml.eu" || s.MaxIdleConnections != 7 {
+		t.Errorf("New(FromFile(%q)) = %+v, want Host=https://yaml.eu MaxIdleConnections=7", path, s)
+	}
+}
+
+func TestFromFileTOML(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.toml", "# comment\nhost = \"https://toml.eu\"\nmax_idle_connections = 9\n")
+
+	s, err := New(FromFile(path))
+	if err != nil {
+		t.Fatalf("New(FromFile(%q)) error = %v", path, err)
+	}
+
+	if s.Host != "https://toml.eu" || s.MaxIdleConnections != 9 {
+		t.Errorf("New(FromFile(%q)) = %+v, want Host=https://toml.eu MaxIdleConnections=9", path, s)
+	}
+}
+
+func TestFromFileServerPrefixedKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.toml", "server.host = \"https://prefixed.eu\"\n")
+
+	s, err := New(FromFile(path))
+	if err != nil {
+		t.Fatalf("New(FromFile(%q)) error = %v", path, err)
+	}
+
+	if s.Host != "https://prefixed.eu" {
+		t.Errorf("New(FromFile(%q)).Host = %q, want https://prefixed.eu", path, s.Host)
+	}
+}
+
+func TestFromFileUnknownKey(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.json", `{"nonexistent_field": "x"}`)
+
+	if _, err := New(FromFile(path)); err == nil {
+		t.Fatal("New(FromFile(...)) error = nil, want an unknown-key error")
+	}
+}
+
+func TestFromFileMalformedLine(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.yaml", "this line has no separator\n")
+
+	if _, err := New(FromFile(path)); err == nil {
+		t.Fatal("New(FromFile(...)) error = nil, want a malformed-line error")
+	}
+}
+
+func TestFromFileUnsupportedExtension(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.ini", "host = https://ini.eu\n")
+
+	if _, err := New(FromFile(path)); err == nil {
+		t.Fatal("New(FromFile(...)) error = nil, want an unsupported-format error")
+	}
+}
+
+func TestFromFileMissingFile(t *testing.T) {
+	if _, err := New(FromFile(filepath.Join(t.TempDir(), "missing.json"))); err == nil {
+		t.Fatal("New(FromFile(...)) error = nil, want a read error for a missing file")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TESTSRV_HOST", "https://env.eu")
+	t.Setenv("TESTSRV_MAX_IDLE_CONNECTIONS", "15")
+
+	s, err := New(FromEnv("TESTSRV"))
+	if err != nil {
+		t.Fatalf("New(FromEnv(...)) error = %v", err)
+	}
+
+	if s.Host != "https://env.eu" || s.MaxIdleConnections != 15 {
+		t.Errorf("New(FromEnv(...)) = %+v, want Host=https://env.eu MaxIdleConnections=15", s)
+	}
+
+	// MaxSessionConnection wasn't set via env, so it should keep its default.
+	if s.MaxSessionConnection != 5*time.Minute {
+		t.Errorf("New(FromEnv(...)).MaxSessionConnection = %v, want untouched default 5m", s.MaxSessionConnection)
+	}
+}
+
+func TestFromEnvInvalidValue(t *testing.T) {
+	t.Setenv("TESTSRV_MAX_IDLE_CONNECTIONS", "not-a-number")
+
+	if _, err := New(FromEnv("TESTSRV")); err == nil {
+		t.Fatal("New(FromEnv(...)) error = nil, want an error from the invalid env value")
+	}
+}
+
+func TestFromFileThenEnvThenExplicitOrder(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "server.json", `{"host": "https://file.eu", "max_idle_connections": 10}`)
+
+	t.Setenv("TESTSRV_HOST", "https://env.eu")
+
+	s, err := New(
+		FromFile(path),
+		FromEnv("TESTSRV"),
+		WithMaxIdleConnections(99),
+	)
+	if err != nil {
+		t.Fatalf("New(...) error = %v", err)
+	}
+
+	if s.Host != "https://env.eu" {
+		t.Errorf("Host = %q, want env to override file", s.Host)
+	}
+
+	if s.MaxIdleConnections != 99 {
+		t.Errorf("MaxIdleConnections = %d, want explicit option to win last", s.MaxIdleConnections)
+	}
+}
@@ -2,23 +2,57 @@ package main
 
 import (
 	"fmt"
+	"net/url"
+	"reflect"
 	"time"
 )
 
 // Server is a simple example of a struct
+//
+//go:generate optionsgen Server
 type Server struct {
 	Name string
-	Host string
 
-	MaxIdleConnections   uint
-	MaxSessionConnection time.Duration
+	// Host already has a hand-written, validating WithHost below, so it's
+	// excluded from generation to avoid a redeclaration.
+	Host string `options:"-"`
+
+	// MaxIdleConnections already has a hand-written, validating
+	// WithMaxIdleConnections below, so it's excluded from generation.
+	MaxIdleConnections uint `options:"-"`
+
+	// MaxSessionConnection already has a hand-written, validating
+	// WithMaxSessionConnection below, so it's excluded from generation.
+	MaxSessionConnection time.Duration `options:"-"`
+}
+
+// Option configures a Server. It returns an error if the option's value is
+// invalid, so construction can fail fast instead of producing a broken Server.
+type Option interface {
+	apply(s *Server) error
 }
 
-// Option is a function for options
-type Option func(s *Server)
+// optionFunc adapts a plain func(*Server) error to an Option, the same way
+// http.HandlerFunc adapts a func to a Handler.
+type optionFunc func(s *Server) error
+
+func (f optionFunc) apply(s *Server) error { return f(s) }
+
+// Options returns opts unchanged. It exists so callers can build up a slice
+// of Option values and inspect it (with a type switch or HasOption) before
+// or after passing it to New, which plain variadic calls don't allow.
+func Options(opts ...Option) []Option { return opts }
 
-// New server with options
-func New(options ...Option) Server {
+// reversible is implemented by options that know how to undo themselves
+// against a baseline Server, for Undo.
+type reversible interface {
+	Option
+	undo(baseline *Server) Option
+}
+
+// New server with options. It returns the first error produced by an option,
+// if any.
+func New(options ...Option) (Server, error) {
 	// define a default server
 	s := Server{
 		Name:                 "default",
@@ -28,53 +62,296 @@ func New(options ...Option) Server {
 	}
 
 	// apply options for the created server
-	for _, fn := range options {
-		fn(&s)
+	for _, opt := range options {
+		if err := opt.apply(&s); err != nil {
+			return Server{}, err
+		}
+	}
+
+	return s, nil
+}
+
+// MustNew is like New but panics if any option returns an error. It is meant
+// for tests and main, where a bad configuration should fail immediately.
+func MustNew(options ...Option) Server {
+	s, err := New(options...)
+	if err != nil {
+		panic(err)
 	}
 
 	return s
 }
 
-// WithHost option overwrites a default value.
+// hostOption is the concrete, package-private Option behind WithHost. Giving
+// it a named type (instead of a bare closure) lets code in this package
+// inspect or undo it; outside callers can only get one through WithHost.
+type hostOption struct{ host string }
+
+func (o hostOption) apply(s *Server) error {
+	if _, err := url.Parse(o.host); err != nil {
+		return fmt.Errorf("options: invalid host %q: %w", o.host, err)
+	}
+
+	s.Host = o.host
+
+	return nil
+}
+
+func (o hostOption) undo(baseline *Server) Option { return hostOption{host: baseline.Host} }
+
+// WithHost option overwrites a default value. It returns an error if host is
+// not a valid URL.
 func WithHost(host string) Option {
-	return func(s *Server) {
-		s.Host = host
+	return hostOption{host: host}
+}
+
+// maxIdleOption is the concrete Option behind WithMaxIdleConnections.
+type maxIdleOption struct{ n uint }
+
+func (o maxIdleOption) apply(s *Server) error {
+	if o.n == 0 {
+		return fmt.Errorf("options: max idle connections must be > 0")
 	}
+
+	s.MaxIdleConnections = o.n
+
+	return nil
+}
+
+func (o maxIdleOption) undo(baseline *Server) Option {
+	return maxIdleOption{n: baseline.MaxIdleConnections}
 }
 
-// WithMaxIdleConnections option overwrites a default value.
+// WithMaxIdleConnections option overwrites a default value. maxConnections
+// must be greater than 0.
 func WithMaxIdleConnections(maxConnections uint) Option {
-	return func(s *Server) {
-		s.MaxIdleConnections = maxConnections
+	return maxIdleOption{n: maxConnections}
+}
+
+// maxSessionOption is the concrete Option behind WithMaxSessionConnection.
+type maxSessionOption struct{ d time.Duration }
+
+func (o maxSessionOption) apply(s *Server) error {
+	if o.d <= 0 {
+		return fmt.Errorf("options: max session connection must be > 0")
+	}
+
+	s.MaxSessionConnection = o.d
+
+	return nil
+}
+
+func (o maxSessionOption) undo(baseline *Server) Option {
+	return maxSessionOption{d: baseline.MaxSessionConnection}
+}
+
+// WithMaxSessionConnection option overwrites a default value. d must be
+// greater than 0.
+func WithMaxSessionConnection(d time.Duration) Option {
+	return maxSessionOption{d: d}
+}
+
+// WithValidator runs fn against the Server as built so far, the same as any
+// other option — it does not automatically run last. Pass it after the
+// options it needs to validate (typically at the end of the list) for
+// checks that depend on more than one field.
+func WithValidator(fn func(*Server) error) Option {
+	return optionFunc(fn)
+}
+
+// HasOption reports whether opts contains an option of the same concrete
+// type as sample, e.g. HasOption(opts, hostOption{}). It's meant for tests
+// that need to assert a particular option was applied without caring about
+// its value.
+func HasOption(opts []Option, sample Option) bool {
+	want := reflect.TypeOf(sample)
+
+	for _, opt := range opts {
+		if reflect.TypeOf(opt) == want {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Undo returns an Option that, when applied, reverts every field touched by
+// a reversible option in opts back to its value in baseline. Options that
+// don't support undo (e.g. ones built from WithValidator) are skipped.
+func Undo(opts []Option, baseline Server) Option {
+	return optionFunc(func(s *Server) error {
+		for _, opt := range opts {
+			r, ok := opt.(reversible)
+			if !ok {
+				continue
+			}
+
+			if err := r.undo(&baseline).apply(s); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Diff reports the subset of opts that would actually change baseline, by
+// applying them in order against a scratch copy and comparing.
+func Diff(baseline Server, opts []Option) ([]Option, error) {
+	var changed []Option
+
+	current := baseline
+
+	for _, opt := range opts {
+		next := current
+		if err := opt.apply(&next); err != nil {
+			return nil, err
+		}
+
+		if next != current {
+			changed = append(changed, opt)
+		}
+
+		current = next
 	}
+
+	return changed, nil
+}
+
+// Preset is a reusable bundle of options, expanded in order wherever it is
+// applied via WithPreset.
+type Preset []Option
+
+// WithPreset applies every option in p, in order. Like any other options,
+// later options (including ones that come after WithPreset itself) override
+// earlier ones.
+func WithPreset(p Preset) Option {
+	return optionFunc(func(s *Server) error {
+		for _, opt := range p {
+			if err := opt.apply(s); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Compose concatenates two presets into one, preserving order: a is applied
+// in full, then b, so b's options win on conflicting fields.
+func Compose(a, b Preset) Preset {
+	out := make(Preset, 0, len(a)+len(b))
+	out = append(out, a...)
+	out = append(out, b...)
+
+	return out
+}
+
+// PresetProduction configures a Server for a production deployment: a real
+// host, a larger idle connection pool, and longer-lived sessions.
+var PresetProduction = Preset{
+	WithHost("https://prod.eu"),
+	WithMaxIdleConnections(100),
+	WithMaxSessionConnection(10 * time.Minute),
+}
+
+// PresetDevelopment configures a Server for local development: localhost,
+// a small connection pool, and short sessions.
+var PresetDevelopment = Preset{
+	WithHost("http://localhost:8080"),
+	WithMaxIdleConnections(5),
+	WithMaxSessionConnection(time.Minute),
+}
+
+// PresetLowLatency trades session lifetime for a much larger idle
+// connection pool, favoring throughput over long-lived sessions.
+var PresetLowLatency = Preset{
+	WithMaxIdleConnections(200),
+	WithMaxSessionConnection(30 * time.Second),
 }
 
 // main simple example
 func main() {
 	// default server without options
-	defaultServer := New()
+	defaultServer := MustNew()
 	fmt.Println(defaultServer)
 
 	// example, how we can overwrite one value
-	serverWithHost := New(WithHost("https://another-host.eu"))
+	serverWithHost := MustNew(WithHost("https://another-host.eu"))
 	fmt.Println(serverWithHost)
 
 	// or even 2 values
-	serverWithHostAndMaxIdleConnections := New(
+	serverWithHostAndMaxIdleConnections := MustNew(
 		WithHost("https://eu.ru"),
 		WithMaxIdleConnections(50),
 	)
 
 	fmt.Println(serverWithHostAndMaxIdleConnections)
 
+	// or report a configuration error instead of panicking
+	if _, err := New(WithMaxIdleConnections(0)); err != nil {
+		fmt.Println("configuration error:", err)
+	}
+
 	// or even create a custom function
 	// but usually Option function isn't a public one.
 	// the creators of packages allow us use pre-built options (with) functions.
-	customServer := New(func(s *Server) {
+	customServer := MustNew(optionFunc(func(s *Server) error {
 		s.Name = "new Name"
 		s.MaxSessionConnection = 50 * time.Microsecond
 		s.Host = "https://another-host"
-	})
+
+		return nil
+	}))
 
 	fmt.Println(customServer)
+
+	// or start from a preset and override just what's different
+	prodServerInEU := MustNew(
+		WithPreset(PresetProduction),
+		WithHost("https://prod.eu-west"),
+	)
+
+	fmt.Println(prodServerInEU)
+
+	// presets can also be composed together
+	prodLowLatency := MustNew(WithPreset(Compose(PresetProduction, PresetLowLatency)))
+
+	fmt.Println(prodLowLatency)
+
+	// or load config from a file and the environment, in the usual
+	// defaults -> file -> env -> explicit With... order, with explicit
+	// options still winning last. server.json ships next to this file, so
+	// run this with `go run .` from the options directory.
+	configuredServer, err := New(
+		FromFile("server.json"),
+		FromEnv("SERVER"),
+		WithMaxIdleConnections(75),
+	)
+	if err != nil {
+		fmt.Println("configuration error:", err)
+	} else {
+		fmt.Println(configuredServer)
+	}
+
+	// Options lets us build up a slice and inspect it before using it
+	baseline := MustNew()
+	applied := Options(WithHost("https://prod.eu"), WithMaxIdleConnections(100))
+	fmt.Println("has host option:", HasOption(applied, hostOption{}))
+
+	// Diff reports which of those options would actually change baseline
+	changed, err := Diff(baseline, applied)
+	if err != nil {
+		fmt.Println("diff error:", err)
+	} else {
+		fmt.Println("options that change baseline:", len(changed))
+	}
+
+	// and Undo reverts a Server built from applied back to baseline's fields
+	reverted := MustNew(applied...)
+	if err := Undo(applied, baseline).apply(&reverted); err != nil {
+		fmt.Println("undo error:", err)
+	} else {
+		fmt.Println(reverted)
+	}
 }
@@ -0,0 +1,82 @@
+package main
+
+import "testing"
+
+func TestWithPresetAppliesInOrder(t *testing.T) {
+	preset := Preset{
+		WithHost("https://first.eu"),
+		WithHost("https://second.eu"),
+	}
+
+	s, err := New(WithPreset(preset))
+	if err != nil {
+		t.Fatalf("New(WithPreset(...)) error = %v", err)
+	}
+
+	if s.Host != "https://second.eu" {
+		t.Errorf("Host = %q, want the later option in the preset to win", s.Host)
+	}
+}
+
+func TestWithPresetIsOverriddenByLaterOptions(t *testing.T) {
+	s, err := New(
+		WithPreset(PresetProduction),
+		WithHost("https://override.eu"),
+	)
+	if err != nil {
+		t.Fatalf("New(...) error = %v", err)
+	}
+
+	if s.Host != "https://override.eu" {
+		t.Errorf("Host = %q, want the explicit option after WithPreset to win", s.Host)
+	}
+}
+
+func TestWithPresetOverridesEarlierOptions(t *testing.T) {
+	s, err := New(
+		WithHost("https://before.eu"),
+		WithPreset(PresetProduction),
+	)
+	if err != nil {
+		t.Fatalf("New(...) error = %v", err)
+	}
+
+	if s.Host == "https://before.eu" {
+		t.Errorf("Host = %q, want WithPreset applied after to win", s.Host)
+	}
+}
+
+func TestCompose(t *testing.T) {
+	a := Preset{WithHost("https://a.eu"), WithMaxIdleConnections(1)}
+	b := Preset{WithHost("https://b.eu")}
+
+	composed := Compose(a, b)
+
+	if len(composed) != len(a)+len(b) {
+		t.Fatalf("Compose(...) has %d options, want %d", len(composed), len(a)+len(b))
+	}
+
+	s, err := New(WithPreset(composed))
+	if err != nil {
+		t.Fatalf("New(WithPreset(Compose(...))) error = %v", err)
+	}
+
+	if s.Host != "https://b.eu" {
+		t.Errorf("Host = %q, want b's option to win over a's", s.Host)
+	}
+
+	if s.MaxIdleConnections != 1 {
+		t.Errorf("MaxIdleConnections = %d, want a's option to still apply", s.MaxIdleConnections)
+	}
+}
+
+func TestComposeDoesNotMutateInputs(t *testing.T) {
+	a := Preset{WithHost("https://a.eu")}
+	b := Preset{WithHost("https://b.eu")}
+
+	_ = Compose(a, b)
+
+	if len(a) != 1 || len(b) != 1 {
+		t.Fatalf("Compose(...) mutated its inputs: len(a)=%d len(b)=%d", len(a), len(b))
+	}
+}
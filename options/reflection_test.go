@@ -0,0 +1,108 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHasOption(t *testing.T) {
+	opts := Options(WithHost("https://example.eu"), WithMaxIdleConnections(50))
+
+	if !HasOption(opts, hostOption{}) {
+		t.Error("HasOption(opts, hostOption{}) = false, want true")
+	}
+
+	if !HasOption(opts, maxIdleOption{}) {
+		t.Error("HasOption(opts, maxIdleOption{}) = false, want true")
+	}
+
+	if HasOption(opts, maxSessionOption{}) {
+		t.Error("HasOption(opts, maxSessionOption{}) = true, want false")
+	}
+}
+
+func TestDiffReportsOnlyChangedOptions(t *testing.T) {
+	baseline := MustNew()
+
+	opts := []Option{
+		WithHost(baseline.Host), // same as baseline, should not count as changed
+		WithMaxIdleConnections(baseline.MaxIdleConnections + 1),
+	}
+
+	changed, err := Diff(baseline, opts)
+	if err != nil {
+		t.Fatalf("Diff(...) error = %v", err)
+	}
+
+	if len(changed) != 1 {
+		t.Fatalf("Diff(...) returned %d options, want 1", len(changed))
+	}
+
+	if !HasOption(changed, maxIdleOption{}) {
+		t.Errorf("Diff(...) did not report the changed maxIdleOption")
+	}
+}
+
+func TestDiffPropagatesOptionErrors(t *testing.T) {
+	baseline := MustNew()
+
+	if _, err := Diff(baseline, []Option{WithMaxIdleConnections(0)}); err == nil {
+		t.Error("Diff(...) error = nil, want an error from the invalid option")
+	}
+}
+
+func TestUndoRevertsAppliedOptions(t *testing.T) {
+	baseline := MustNew()
+
+	opts := []Option{
+		WithHost("https://changed.eu"),
+		WithMaxIdleConnections(baseline.MaxIdleConnections + 10),
+		WithMaxSessionConnection(baseline.MaxSessionConnection + time.Minute),
+	}
+
+	applied := baseline
+	for _, opt := range opts {
+		if err := opt.apply(&applied); err != nil {
+			t.Fatalf("apply(...) error = %v", err)
+		}
+	}
+
+	if applied == baseline {
+		t.Fatal("test setup: applying opts did not change the Server")
+	}
+
+	reverted := applied
+	if err := Undo(opts, baseline).apply(&reverted); err != nil {
+		t.Fatalf("Undo(...).apply(...) error = %v", err)
+	}
+
+	if reverted != baseline {
+		t.Errorf("Undo(...) reverted to %+v, want baseline %+v", reverted, baseline)
+	}
+}
+
+func TestUndoSkipsNonReversibleOptions(t *testing.T) {
+	baseline := MustNew()
+
+	validatorRan := false
+	opts := []Option{
+		WithValidator(func(s *Server) error {
+			validatorRan = true
+
+			return nil
+		}),
+	}
+
+	reverted := baseline
+	if err := Undo(opts, baseline).apply(&reverted); err != nil {
+		t.Fatalf("Undo(...).apply(...) error = %v", err)
+	}
+
+	if validatorRan {
+		t.Error("Undo applied a non-reversible option instead of skipping it")
+	}
+
+	if reverted != baseline {
+		t.Errorf("Undo(...) changed the Server to %+v, want it untouched at %+v", reverted, baseline)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNewValidatesOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr bool
+	}{
+		{name: "no options", opts: nil, wantErr: false},
+		{name: "valid host", opts: []Option{WithHost("https://example.eu")}, wantErr: false},
+		{name: "malformed host", opts: []Option{WithHost("://not-a-url")}, wantErr: true},
+		{name: "zero max idle connections", opts: []Option{WithMaxIdleConnections(0)}, wantErr: true},
+		{name: "positive max idle connections", opts: []Option{WithMaxIdleConnections(1)}, wantErr: false},
+		{name: "zero max session connection", opts: []Option{WithMaxSessionConnection(0)}, wantErr: true},
+		{name: "negative max session connection", opts: []Option{WithMaxSessionConnection(-time.Second)}, wantErr: true},
+		{name: "positive max session connection", opts: []Option{WithMaxSessionConnection(time.Second)}, wantErr: false},
+		{
+			name: "failing validator",
+			opts: []Option{WithValidator(func(s *Server) error {
+				return fmt.Errorf("always fails")
+			})},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(tt.opts...)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New(...) error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewDefaults(t *testing.T) {
+	s, err := New()
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	want := Server{
+		Name:                 "default",
+		Host:                 "http://default-eu",
+		MaxIdleConnections:   20,
+		MaxSessionConnection: 5 * time.Minute,
+	}
+
+	if s != want {
+		t.Errorf("New() = %+v, want %+v", s, want)
+	}
+}
+
+func TestMustNewPanicsOnInvalidOption(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MustNew did not panic on an invalid option")
+		}
+	}()
+
+	MustNew(WithMaxIdleConnections(0))
+}
+
+func TestWithValidatorSeesServerAsBuiltSoFar(t *testing.T) {
+	var seen Server
+
+	_, err := New(
+		WithHost("https://example.eu"),
+		WithValidator(func(s *Server) error {
+			seen = *s
+
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New(...) error = %v", err)
+	}
+
+	if seen.Host != "https://example.eu" {
+		t.Errorf("validator saw Host = %q, want the already-applied host", seen.Host)
+	}
+}
+
+func TestWithValidatorDoesNotSeeLaterOptions(t *testing.T) {
+	var seenHost string
+
+	_, err := New(
+		WithValidator(func(s *Server) error {
+			seenHost = s.Host
+
+			return nil
+		}),
+		WithHost("https://after-validator.eu"),
+	)
+	if err != nil {
+		t.Fatalf("New(...) error = %v", err)
+	}
+
+	if seenHost == "https://after-validator.eu" {
+		t.Error("validator saw a host set by an option that ran after it; WithValidator does not run last")
+	}
+}